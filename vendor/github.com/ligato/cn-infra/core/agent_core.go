@@ -15,8 +15,10 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/ligato/cn-infra/logging"
@@ -37,6 +39,16 @@ type Agent struct {
 	logging.Logger
 	// agent startup details
 	startup
+
+	// ctx is the Agent-owned root context. Init()/AfterInit() of plugins that
+	// implement ContextPlugin/ContextPostInit receive a context derived from
+	// it, and cancel is invoked by Stop() before plugins are closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// eventsMu guards subscribers.
+	eventsMu    sync.Mutex
+	subscribers []chan<- PluginEvent
 }
 
 type startup struct {
@@ -46,10 +58,63 @@ type startup struct {
 	initDuration time.Duration
 	// successfully after-initialized plugins
 	afterInitDuration time.Duration
+	// processingMu guards currentlyProcessing, initDuration and
+	// afterInitDuration, all of which are written by wave worker goroutines
+	// spawned from initPlugins/handleAfterInit while Start() concurrently
+	// reads them on the select's timeout and done paths.
+	processingMu sync.Mutex
 	// the field is set before initialization of every plugin with its name
 	currentlyProcessing string
 }
 
+// setInitDuration records initDuration under processingMu, since it is read
+// concurrently by Start() while initPlugins runs in its own goroutine.
+func (agent *Agent) setInitDuration(d time.Duration) {
+	agent.processingMu.Lock()
+	agent.initDuration = d
+	agent.processingMu.Unlock()
+}
+
+// getInitDuration returns the duration last recorded by setInitDuration.
+func (agent *Agent) getInitDuration() time.Duration {
+	agent.processingMu.Lock()
+	defer agent.processingMu.Unlock()
+	return agent.initDuration
+}
+
+// setAfterInitDuration records afterInitDuration under processingMu, since it
+// is read concurrently by Start() while handleAfterInit runs in its own
+// goroutine.
+func (agent *Agent) setAfterInitDuration(d time.Duration) {
+	agent.processingMu.Lock()
+	agent.afterInitDuration = d
+	agent.processingMu.Unlock()
+}
+
+// getAfterInitDuration returns the duration last recorded by
+// setAfterInitDuration.
+func (agent *Agent) getAfterInitDuration() time.Duration {
+	agent.processingMu.Lock()
+	defer agent.processingMu.Unlock()
+	return agent.afterInitDuration
+}
+
+// setCurrentlyProcessing records the plugin a wave worker is currently
+// handling, for the timeout error messages produced if MaxStartupTime elapses.
+func (agent *Agent) setCurrentlyProcessing(name PluginName) {
+	agent.processingMu.Lock()
+	agent.currentlyProcessing = string(name)
+	agent.processingMu.Unlock()
+}
+
+// getCurrentlyProcessing returns the plugin name last recorded by
+// setCurrentlyProcessing.
+func (agent *Agent) getCurrentlyProcessing() string {
+	agent.processingMu.Lock()
+	defer agent.processingMu.Unlock()
+	return agent.currentlyProcessing
+}
+
 const (
 	logErrorFmt        = "plugin %s: Init error '%s', took %v"
 	logSuccessFmt      = "plugin %s: Init took %v"
@@ -59,9 +124,13 @@ const (
 	logAfterSuccessFmt = "plugin %s: AfterInit took %v"
 	logNoAfterInitFmt  = "plugin %s: not implement AfterInit"
 	logTimeoutFmt      = "plugin %s not completed before timeout"
+	logStopTimeoutFmt  = "agent Stop() not completed before timeout, currently closing %s"
 	// The default value serves as an indicator for timer still running even after MaxStartupTime. Used in case
 	// some plugin lasts long time to load or is stuck
 	defaultTimerValue = -1
+	// defaultMaxParallelInit bounds how many plugins in the same dependency
+	// wave are initialized concurrently.
+	defaultMaxParallelInit = 8
 )
 
 // NewAgent returns a new instance of the Agent with plugins.
@@ -69,14 +138,18 @@ const (
 // but not for the plugins themselves.
 // <maxStartup> puts a time limit on initialization of all provided plugins.
 // Agent.Start() returns ErrPluginsInitTimeout error if one or more plugins fail
-// to initialize inside the specified time limit.
+// to initialize inside the specified time limit. The same duration bounds
+// Agent.Stop(), so a plugin whose Close() hangs cannot block shutdown forever.
 // <plugins> is a variable list of plugins to load. ListPluginsInFlavor() helper
 // method can be used to obtain the list from a given flavor.
 func NewAgent(logger logging.Logger, maxStartup time.Duration, plugins ...*NamedPlugin) *Agent {
+	ctx, cancel := context.WithCancel(context.Background())
 	a := Agent{
-		plugins,
-		logger,
-		startup{MaxStartupTime: maxStartup},
+		plugins: plugins,
+		Logger:  logger,
+		startup: startup{MaxStartupTime: maxStartup},
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 	return &a
 }
@@ -103,14 +176,33 @@ func (agent *Agent) Start() error {
 		flag.Parse()
 	}
 
+	// startupCtx is the single deadline shared by both Init() and AfterInit()
+	// across every dependency wave; it is cancelled once MaxStartupTime elapses
+	// or startup fails, so any in-flight ContextPlugin goroutines are stopped
+	// instead of merely abandoned the way the outer select below would on its
+	// own. After a successful start it stays live until Stop() cancels the
+	// Agent's root context it is derived from.
+	startupCtx, cancelStartup := context.WithCancel(agent.ctx)
+
 	go func() {
-		err := agent.initPlugins()
+		// The DAG is built once and reused for both phases, so AfterInit()
+		// follows exactly the same wave order Init() did.
+		waves, err := agent.topoWaves()
+		if err != nil {
+			cancelStartup()
+			errChannel <- err
+			return
+		}
+
+		err = agent.initPlugins(startupCtx, waves)
 		if err != nil {
+			cancelStartup()
 			errChannel <- err
 			return
 		}
-		err = agent.handleAfterInit()
+		err = agent.handleAfterInit(startupCtx, waves)
 		if err != nil {
+			cancelStartup()
 			errChannel <- err
 			return
 		}
@@ -120,99 +212,182 @@ func (agent *Agent) Start() error {
 	//block until all Plugins are initialized or timeout expires
 	select {
 	case err := <-errChannel:
-		agent.WithField("durationInNs", agent.initDuration.Nanoseconds()).Infof("Agent Init took %v", agent.initDuration)
-		agent.WithField("durationInNs", agent.afterInitDuration.Nanoseconds()).Infof("Agent AfterInit took %v", agent.afterInitDuration)
+		agent.WithField("durationInNs", agent.getInitDuration().Nanoseconds()).Infof("Agent Init took %v", agent.getInitDuration())
+		agent.WithField("durationInNs", agent.getAfterInitDuration().Nanoseconds()).Infof("Agent AfterInit took %v", agent.getAfterInitDuration())
 		return err
 	case <-doneChannel:
-		agent.WithField("durationInNs", agent.initDuration.Nanoseconds()).Infof("Agent Init took %v", agent.initDuration)
-		agent.WithField("durationInNs", agent.afterInitDuration.Nanoseconds()).Infof("Agent AfterInit took %v", agent.afterInitDuration)
-		duration := agent.initDuration + agent.afterInitDuration
+		agent.WithField("durationInNs", agent.getInitDuration().Nanoseconds()).Infof("Agent Init took %v", agent.getInitDuration())
+		agent.WithField("durationInNs", agent.getAfterInitDuration().Nanoseconds()).Infof("Agent AfterInit took %v", agent.getAfterInitDuration())
+		duration := agent.getInitDuration() + agent.getAfterInitDuration()
 		agent.WithField("durationInNs", duration.Nanoseconds()).Info(fmt.Sprintf("All plugins initialized successfully, took %v", duration))
+		agent.publish(AgentStarted{Duration: duration})
 		return nil
 	case <-time.After(agent.MaxStartupTime):
-		if agent.initDuration == defaultTimerValue {
+		cancelStartup()
+		if agent.getInitDuration() == defaultTimerValue {
 			agent.Infof("Agent Init took > %v", agent.MaxStartupTime)
-			agent.WithField("durationInNs", agent.afterInitDuration.Nanoseconds()).Infof("Agent AfterInit took %v", agent.afterInitDuration)
-		} else if agent.afterInitDuration == defaultTimerValue {
-			agent.WithField("durationInNs", agent.initDuration.Nanoseconds()).Infof("Agent Init took %v", agent.initDuration)
+			agent.WithField("durationInNs", agent.getAfterInitDuration().Nanoseconds()).Infof("Agent AfterInit took %v", agent.getAfterInitDuration())
+		} else if agent.getAfterInitDuration() == defaultTimerValue {
+			agent.WithField("durationInNs", agent.getInitDuration().Nanoseconds()).Infof("Agent Init took %v", agent.getInitDuration())
 			agent.Infof("Agent AfterInit took > %v", agent.MaxStartupTime)
 		}
 
-		return fmt.Errorf(logTimeoutFmt, agent.currentlyProcessing)
+		return fmt.Errorf(logTimeoutFmt, agent.getCurrentlyProcessing())
 	}
 }
 
 // Stop gracefully shuts down the Agent. It is called usually when the user
 // interrupts the Agent from the EventLoopWithInterrupt().
 //
-// This implementation tries to call Close() method on every plugin on the list
-// in the reverse order. It continues even if some error occurred.
+// Stop first cancels the Agent's root context, so that plugins implementing
+// ContextPlugin/ContextPostInit can tell their background goroutines to wind
+// down, then calls Close() on every plugin on the list in the reverse order.
+// It continues even if some error occurred. The whole shutdown is bounded by
+// MaxStartupTime, the same limit Start() uses, so a plugin whose Close() hangs
+// cannot block the agent from returning.
 func (agent *Agent) Stop() error {
 	agent.Info("Stopping agent...")
+	agent.publish(AgentStopping{})
+
+	if agent.cancel != nil {
+		agent.cancel()
+	}
+
+	doneChannel := make(chan error, 1)
+	go func() {
+		doneChannel <- agent.closePlugins()
+	}()
+
+	select {
+	case err := <-doneChannel:
+		agent.Debug("Agent stopped")
+		return err
+	case <-time.After(agent.MaxStartupTime):
+		return fmt.Errorf(logStopTimeoutFmt, agent.getCurrentlyProcessing())
+	}
+}
+
+// closePlugins calls Close() on every plugin on the list in the reverse order,
+// collecting (rather than stopping on) the first error from each.
+func (agent *Agent) closePlugins() error {
 	errMsg := ""
 	for i := len(agent.plugins) - 1; i >= 0; i-- {
-		agent.WithField("pluginName", agent.plugins[i].PluginName).Debug("Stopping plugin begin")
-		err := safeclose.Close(agent.plugins[i].Plugin)
-		if err != nil {
+		if err := agent.closeOne(agent.plugins[i]); err != nil {
 			if len(errMsg) > 0 {
 				errMsg += "; "
 			}
 			errMsg += string(agent.plugins[i].PluginName)
 			errMsg += ": " + err.Error()
 		}
-		agent.WithField("pluginName", agent.plugins[i].PluginName).Debug("Stopping plugin end ", err)
 	}
 
-	agent.Debug("Agent stopped")
-
 	if len(errMsg) > 0 {
 		return errors.New(errMsg)
 	}
 	return nil
 }
 
-// initPlugins calls Init() an all plugins on the list
-func (agent *Agent) initPlugins() error {
-	// Flag indicates that some of the plugins failed to initialize
-	var initPluginCounter int
-	var pluginFailed bool
-	var wasError error
+// closeOne calls Close() on a single plugin, publishing the matching
+// PluginCloseStarted/Succeeded/Failed events around the call.
+func (agent *Agent) closeOne(plugin *NamedPlugin) error {
+	agent.setCurrentlyProcessing(plugin.PluginName)
+	agent.WithField("pluginName", plugin.PluginName).Debug("Stopping plugin begin")
+	agent.publish(PluginCloseStarted{PluginName: plugin.PluginName})
+
+	err := safeclose.Close(plugin.Plugin)
+	if err != nil {
+		agent.publish(PluginCloseFailed{PluginName: plugin.PluginName, Err: err})
+	} else {
+		agent.publish(PluginCloseSucceeded{PluginName: plugin.PluginName})
+	}
+
+	agent.WithField("pluginName", plugin.PluginName).Debug("Stopping plugin end ", err)
+	return err
+}
 
-	agent.initDuration = defaultTimerValue
+// pluginInit calls Init(), passing the Agent's root context to plugins that
+// implement ContextPlugin and falling back to the argument-less Init() for
+// plugins that only implement Plugin.
+func pluginInit(ctx context.Context, plugin interface{}) error {
+	switch p := plugin.(type) {
+	case ContextPlugin:
+		return p.Init(ctx)
+	case Plugin:
+		return p.Init()
+	default:
+		return fmt.Errorf("plugin does not implement Init()")
+	}
+}
+
+// pluginAfterInit calls AfterInit() the same way pluginInit calls Init(), and
+// reports whether the plugin implements either variant at all.
+func pluginAfterInit(ctx context.Context, plugin interface{}) (implements bool, err error) {
+	switch p := plugin.(type) {
+	case ContextPostInit:
+		return true, p.AfterInit(ctx)
+	case PostInit:
+		return true, p.AfterInit()
+	default:
+		return false, nil
+	}
+}
+
+// initResult carries the outcome of initializing (or after-initializing) a
+// single plugin back from its worker goroutine.
+type initResult struct {
+	plugin *NamedPlugin
+	err    error
+}
+
+// initPlugins calls Init() on every plugin in waves - the dependency DAG
+// declared by the plugins' optional DependsOn() methods - running each wave
+// of mutually independent plugins concurrently (bounded by
+// defaultMaxParallelInit) instead of strictly in list order. If any plugin in
+// a wave fails, the remaining waves are skipped and every plugin initialized
+// so far is closed in reverse topological order.
+func (agent *Agent) initPlugins(ctx context.Context, waves [][]*NamedPlugin) error {
+	agent.setInitDuration(defaultTimerValue)
 	initStartTime := time.Now()
-	for index, plugin := range agent.plugins {
-		initPluginCounter = index
 
-		// set currently initialized plugin name
-		agent.currentlyProcessing = string(plugin.PluginName)
+	var initialized []*NamedPlugin
+	var wasError error
 
-		// skip all other plugins if some of them failed
-		if pluginFailed {
-			agent.Info(fmt.Sprintf(logSkippedFmt, plugin.PluginName))
+	for _, wave := range waves {
+		if wasError != nil {
+			for _, plugin := range wave {
+				agent.Info(fmt.Sprintf(logSkippedFmt, plugin.PluginName))
+				agent.publish(PluginInitSkipped{PluginName: plugin.PluginName})
+			}
 			continue
 		}
 
-		pluginStartTime := time.Now()
-		err := plugin.Init()
-		if err != nil {
-			pluginErrTime := time.Since(pluginStartTime)
-			agent.WithField("durationInNs", pluginErrTime.Nanoseconds()).Errorf(logErrorFmt, plugin.PluginName, err, pluginErrTime)
-
-			pluginFailed = true
-			wasError = fmt.Errorf(logErrorFmt, plugin.PluginName, err, pluginErrTime)
-		} else {
-			pluginSuccTime := time.Since(pluginStartTime)
-			agent.WithField("durationInNs", pluginSuccTime.Nanoseconds()).Infof(logSuccessFmt, plugin.PluginName, pluginSuccTime)
+		select {
+		case <-ctx.Done():
+			for _, plugin := range wave {
+				agent.Info(fmt.Sprintf(logSkippedFmt, plugin.PluginName))
+				agent.publish(PluginInitSkipped{PluginName: plugin.PluginName})
+			}
+			wasError = fmt.Errorf(logTimeoutFmt, agent.getCurrentlyProcessing())
+			continue
+		default:
+		}
+
+		for _, result := range agent.runWave(ctx, wave, agent.initOne) {
+			if result.err != nil && wasError == nil {
+				wasError = result.err
+			}
+			if result.err == nil {
+				initialized = append(initialized, result.plugin)
+			}
 		}
 	}
-	agent.initDuration = time.Since(initStartTime)
+	agent.setInitDuration(time.Since(initStartTime))
 
 	if wasError != nil {
-		//Stop the plugins that are initialized
-		for i := initPluginCounter; i >= 0; i-- {
-			agent.Debugf("Closing %v", agent.plugins[i])
-			err := safeclose.Close(agent.plugins[i])
-			if err != nil {
+		// Stop the plugins that were initialized, in reverse topological order.
+		for i := len(initialized) - 1; i >= 0; i-- {
+			agent.Debugf("Closing %v", initialized[i])
+			if err := agent.closeOne(initialized[i]); err != nil {
 				wasError = err
 			}
 		}
@@ -221,48 +396,114 @@ func (agent *Agent) initPlugins() error {
 	return nil
 }
 
+// runWave runs call for every plugin in wave concurrently, bounded by
+// defaultMaxParallelInit in-flight calls at a time, and waits for all of them
+// to finish before returning their results (order matches wave).
+func (agent *Agent) runWave(ctx context.Context, wave []*NamedPlugin, call func(ctx context.Context, plugin *NamedPlugin) error) []initResult {
+	results := make([]initResult, len(wave))
+	sem := make(chan struct{}, defaultMaxParallelInit)
+	var wg sync.WaitGroup
+
+	for i, plugin := range wave {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, plugin *NamedPlugin) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = initResult{plugin: plugin, err: call(ctx, plugin)}
+		}(i, plugin)
+	}
+	wg.Wait()
+	return results
+}
+
+// initOne calls Init() on a single plugin, publishing the matching
+// PluginInitStarted/Succeeded/Failed events around the call.
+func (agent *Agent) initOne(ctx context.Context, plugin *NamedPlugin) error {
+	agent.setCurrentlyProcessing(plugin.PluginName)
+	agent.publish(PluginInitStarted{PluginName: plugin.PluginName})
+
+	pluginStartTime := time.Now()
+	err := pluginInit(ctx, plugin.Plugin)
+	duration := time.Since(pluginStartTime)
+
+	if err != nil {
+		agent.WithField("durationInNs", duration.Nanoseconds()).Errorf(logErrorFmt, plugin.PluginName, err, duration)
+		agent.publish(PluginInitFailed{PluginName: plugin.PluginName, Err: err, Duration: duration})
+		return fmt.Errorf(logErrorFmt, plugin.PluginName, err, duration)
+	}
+
+	agent.WithField("durationInNs", duration.Nanoseconds()).Infof(logSuccessFmt, plugin.PluginName, duration)
+	agent.publish(PluginInitSucceeded{PluginName: plugin.PluginName, Duration: duration})
+	return nil
+}
+
 // handleAfterInit calls the AfterInit handlers for plugins that can only
-// finish their initialization after  all other plugins have been initialized.
-func (agent *Agent) handleAfterInit() error {
-	// Flag indicates that some of the plugins failed to after-initialize
-	var pluginFailed bool
+// finish their initialization after all other plugins have been initialized.
+// It runs the same dependency waves as initPlugins, in the same order, so a
+// plugin's AfterInit() never starts before the Init() of its dependencies.
+func (agent *Agent) handleAfterInit(ctx context.Context, waves [][]*NamedPlugin) error {
 	var wasError error
 
-	agent.afterInitDuration = defaultTimerValue
+	agent.setAfterInitDuration(defaultTimerValue)
 	afterInitStartTime := time.Now()
-	for _, plug := range agent.plugins {
-		// set currently after-initialized plugin name
-		agent.currentlyProcessing = string(plug.PluginName)
+	for _, wave := range waves {
+		if wasError != nil {
+			for _, plugin := range wave {
+				agent.Info(fmt.Sprintf(logAfterSkippedFmt, plugin.PluginName))
+				agent.publish(PluginAfterInitSkipped{PluginName: plugin.PluginName})
+			}
+			continue
+		}
 
-		// skip all other plugins if some of them failed
-		if pluginFailed {
-			agent.Info(fmt.Sprintf(logAfterSkippedFmt, plug.PluginName))
+		select {
+		case <-ctx.Done():
+			for _, plugin := range wave {
+				agent.Info(fmt.Sprintf(logAfterSkippedFmt, plugin.PluginName))
+				agent.publish(PluginAfterInitSkipped{PluginName: plugin.PluginName})
+			}
+			wasError = fmt.Errorf(logTimeoutFmt, agent.getCurrentlyProcessing())
 			continue
+		default:
 		}
 
-		// Check if plugin implements AfterInit()
-		if plugin, ok := plug.Plugin.(PostInit); ok {
-			pluginStartTime := time.Now()
-			err := plugin.AfterInit()
-			if err != nil {
-				pluginErrTime := time.Since(pluginStartTime)
-				agent.WithField("durationInNs", pluginErrTime.Nanoseconds()).Errorf(logAfterErrorFmt, plug.PluginName, err, pluginErrTime)
-
-				pluginFailed = true
-				wasError = fmt.Errorf(logAfterErrorFmt, plug.PluginName, err, pluginErrTime)
-			} else {
-				pluginSuccTime := time.Since(pluginStartTime)
-				agent.WithField("durationInNs", pluginSuccTime.Nanoseconds()).Infof(logAfterSuccessFmt, plug.PluginName, pluginSuccTime)
+		for _, result := range agent.runWave(ctx, wave, agent.afterInitOne) {
+			if result.err != nil && wasError == nil {
+				wasError = result.err
 			}
-		} else {
-			agent.Info(fmt.Sprintf(logNoAfterInitFmt, plug.PluginName))
 		}
 	}
-	agent.afterInitDuration = time.Since(afterInitStartTime)
+	agent.setAfterInitDuration(time.Since(afterInitStartTime))
 
 	if wasError != nil {
 		agent.Stop()
 		return wasError
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// afterInitOne calls AfterInit() on a single plugin, publishing the matching
+// PluginAfterInitStarted/Succeeded/Failed/Skipped events around the call.
+func (agent *Agent) afterInitOne(ctx context.Context, plugin *NamedPlugin) error {
+	agent.setCurrentlyProcessing(plugin.PluginName)
+	agent.publish(PluginAfterInitStarted{PluginName: plugin.PluginName})
+
+	pluginStartTime := time.Now()
+	implements, err := pluginAfterInit(ctx, plugin.Plugin)
+	duration := time.Since(pluginStartTime)
+
+	if !implements {
+		agent.Info(fmt.Sprintf(logNoAfterInitFmt, plugin.PluginName))
+		agent.publish(PluginAfterInitSkipped{PluginName: plugin.PluginName})
+		return nil
+	}
+	if err != nil {
+		agent.WithField("durationInNs", duration.Nanoseconds()).Errorf(logAfterErrorFmt, plugin.PluginName, err, duration)
+		agent.publish(PluginAfterInitFailed{PluginName: plugin.PluginName, Err: err, Duration: duration})
+		return fmt.Errorf(logAfterErrorFmt, plugin.PluginName, err, duration)
+	}
+
+	agent.WithField("durationInNs", duration.Nanoseconds()).Infof(logAfterSuccessFmt, plugin.PluginName, duration)
+	agent.publish(PluginAfterInitSucceeded{PluginName: plugin.PluginName, Duration: duration})
+	return nil
+}
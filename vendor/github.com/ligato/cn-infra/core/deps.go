@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "fmt"
+
+// DependsOn can be optionally implemented by a plugin to declare the other
+// plugins that must finish initializing before it starts. core.Agent uses it
+// to build a dependency DAG and run mutually independent plugins' Init() and
+// AfterInit() concurrently instead of strictly in declaration order.
+//
+// A plugin that does not implement DependsOn() is instead treated as
+// depending on every plugin before it in the list passed to NewAgent, so that
+// a flavor which declares no dependencies at all keeps behaving exactly like
+// the fully sequential startup of old.
+type DependsOn interface {
+	DependsOn() []PluginName
+}
+
+// topoWaves groups agent.plugins into waves: every plugin in a wave has all of
+// its dependencies satisfied by the plugins in the previous waves, so the
+// plugins within a single wave can be initialized concurrently. The waves
+// themselves must still run in order.
+func (agent *Agent) topoWaves() ([][]*NamedPlugin, error) {
+	known := make(map[PluginName]struct{}, len(agent.plugins))
+	for _, plugin := range agent.plugins {
+		known[plugin.PluginName] = struct{}{}
+	}
+
+	deps := make(map[PluginName]map[PluginName]struct{}, len(agent.plugins))
+	for index, plugin := range agent.plugins {
+		d := make(map[PluginName]struct{})
+		if withDeps, ok := plugin.Plugin.(DependsOn); ok {
+			for _, name := range withDeps.DependsOn() {
+				if _, ok := known[name]; !ok {
+					return nil, fmt.Errorf("plugin %s depends on unknown plugin %s", plugin.PluginName, name)
+				}
+				d[name] = struct{}{}
+			}
+		} else {
+			for _, earlier := range agent.plugins[:index] {
+				d[earlier.PluginName] = struct{}{}
+			}
+		}
+		deps[plugin.PluginName] = d
+	}
+
+	done := make(map[PluginName]struct{}, len(agent.plugins))
+	var waves [][]*NamedPlugin
+	for len(done) < len(agent.plugins) {
+		var wave []*NamedPlugin
+		for _, plugin := range agent.plugins {
+			if _, ok := done[plugin.PluginName]; ok {
+				continue
+			}
+			ready := true
+			for dep := range deps[plugin.PluginName] {
+				if _, ok := done[dep]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, plugin)
+			}
+		}
+		if len(wave) == 0 {
+			return nil, fmt.Errorf("cycle detected in plugin DependsOn() declarations")
+		}
+		for _, plugin := range wave {
+			done[plugin.PluginName] = struct{}{}
+		}
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "time"
+
+// PluginEvent is implemented by every event the Agent publishes on its event
+// bus, so subsystems such as health probes or a metrics exporter can react to
+// a plugin's lifecycle transitions without polling or scraping logs.
+type PluginEvent interface {
+	isPluginEvent()
+}
+
+// pluginEvent is embedded by every concrete PluginEvent defined in this
+// package to satisfy the marker interface without repeating an empty method
+// on each type.
+type pluginEvent struct{}
+
+func (pluginEvent) isPluginEvent() {}
+
+// Event can be embedded by a PluginEvent type defined outside this package -
+// e.g. a ResyncCompleted event published by a resync scheduler - so it
+// satisfies PluginEvent without core needing to know about it upfront.
+type Event struct {
+	pluginEvent
+}
+
+// PluginInitStarted is published right before a plugin's Init() is called.
+type PluginInitStarted struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginInitSucceeded is published when a plugin's Init() returns without error.
+type PluginInitSucceeded struct {
+	pluginEvent
+	PluginName PluginName
+	Duration   time.Duration
+}
+
+// PluginInitFailed is published when a plugin's Init() returns an error.
+type PluginInitFailed struct {
+	pluginEvent
+	PluginName PluginName
+	Err        error
+	Duration   time.Duration
+}
+
+// PluginInitSkipped is published for every plugin whose Init() is skipped
+// because a plugin in an earlier dependency wave already failed to initialize,
+// or because the startup context was cancelled before the plugin's wave began.
+type PluginInitSkipped struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginAfterInitStarted is published right before a plugin's AfterInit() is called.
+type PluginAfterInitStarted struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginAfterInitSucceeded is published when a plugin's AfterInit() returns without error.
+type PluginAfterInitSucceeded struct {
+	pluginEvent
+	PluginName PluginName
+	Duration   time.Duration
+}
+
+// PluginAfterInitFailed is published when a plugin's AfterInit() returns an error.
+type PluginAfterInitFailed struct {
+	pluginEvent
+	PluginName PluginName
+	Err        error
+	Duration   time.Duration
+}
+
+// PluginAfterInitSkipped is published for every plugin whose AfterInit() is
+// skipped because the AfterInit() of a plugin in an earlier dependency wave
+// already failed, because the startup context was cancelled before the
+// plugin's wave began, or because the plugin does not implement
+// PostInit/ContextPostInit at all.
+type PluginAfterInitSkipped struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginCloseStarted is published right before a plugin's Close() is called.
+type PluginCloseStarted struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginCloseSucceeded is published when a plugin's Close() returns without error.
+type PluginCloseSucceeded struct {
+	pluginEvent
+	PluginName PluginName
+}
+
+// PluginCloseFailed is published when a plugin's Close() returns an error.
+type PluginCloseFailed struct {
+	pluginEvent
+	PluginName PluginName
+	Err        error
+}
+
+// AgentStarted is published once Init() and AfterInit() have succeeded for
+// every plugin.
+type AgentStarted struct {
+	pluginEvent
+	Duration time.Duration
+}
+
+// AgentStopping is published at the very start of Agent.Stop(), before the
+// root context is cancelled or any plugin is closed.
+type AgentStopping struct {
+	pluginEvent
+}
+
+// EventsOverflowed is what publish tries to send to a subscriber in place of
+// an event that did not fit because the subscriber's channel was full.
+// Publishing is non-blocking, so a slow or stalled subscriber drops events
+// instead of stalling Agent.Start() or Agent.Stop(). The EventsOverflowed is
+// itself a second non-blocking send right after the drop, so it arrives only
+// if the subscriber freed room in its channel in between; otherwise the drop
+// is not reported to the subscriber at all.
+type EventsOverflowed struct {
+	pluginEvent
+}
+
+// ReconnectEvent is implemented by any PluginEvent that signals a plugin's
+// backing connection came back after being lost - e.g. an etcd client
+// reporting it re-established its session after a transient outage. A
+// subsystem such as a resync scheduler can Subscribe and react to any such
+// event without the Agent or core package needing to know about the
+// plugin-specific event type ahead of time.
+type ReconnectEvent interface {
+	PluginEvent
+	isReconnectEvent()
+}
+
+// Reconnect can be embedded by a plugin-specific event type - the same way
+// Event is embedded - to mark it as a ReconnectEvent.
+type Reconnect struct {
+	Event
+}
+
+func (Reconnect) isReconnectEvent() {}
+
+// publish delivers event to every subscriber without blocking. A subscriber
+// whose channel is full has the event dropped and, only if its channel has
+// room again by the immediately following attempt, an EventsOverflowed in its
+// place.
+func (agent *Agent) publish(event PluginEvent) {
+	agent.eventsMu.Lock()
+	subscribers := make([]chan<- PluginEvent, len(agent.subscribers))
+	copy(subscribers, agent.subscribers)
+	agent.eventsMu.Unlock()
+
+	for _, ch := range subscribers {
+		select {
+		case ch <- event:
+		default:
+			select {
+			case ch <- (EventsOverflowed{}):
+			default:
+			}
+		}
+	}
+}
+
+// Publish lets subsystems outside the plugin lifecycle itself - e.g. a resync
+// scheduler reacting to plugin events - publish their own PluginEvent on the
+// same bus, with the same non-blocking delivery as the Agent's own lifecycle
+// events.
+func (agent *Agent) Publish(event PluginEvent) {
+	agent.publish(event)
+}
+
+// Subscribe registers ch to receive every PluginEvent published by the agent
+// from this point on. Delivery never blocks the agent: a full ch drops the
+// event (see EventsOverflowed). The returned unsubscribe function removes ch
+// from the subscriber list; it is safe to call more than once.
+func (agent *Agent) Subscribe(ch chan<- PluginEvent) (unsubscribe func()) {
+	agent.eventsMu.Lock()
+	agent.subscribers = append(agent.subscribers, ch)
+	agent.eventsMu.Unlock()
+
+	return func() {
+		agent.eventsMu.Lock()
+		defer agent.eventsMu.Unlock()
+		for i, sub := range agent.subscribers {
+			if sub == ch {
+				agent.subscribers = append(agent.subscribers[:i], agent.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "context"
+
+// Plugin interface, all plugins are required to implement.
+type Plugin interface {
+	// Init is called in the agent`s startup phase.
+	Init() error
+	// Close is called in the agent`s cleanup phase.
+	Close() error
+}
+
+// PostInit interface can be optionally implemented by plugins whose
+// initialization needs to happen after Init() of all plugins has returned
+// without error.
+type PostInit interface {
+	// AfterInit is called once Init() of all plugins have returned without error.
+	AfterInit() error
+}
+
+// ContextPlugin is the context-aware counterpart of Plugin. Plugins that start
+// background goroutines in Init() should implement it instead: the context
+// passed to Init is derived from the Agent's root context and is cancelled by
+// Agent.Stop() before Close() is called, giving those goroutines a uniform
+// signal to stop. core.Agent detects this interface via type assertion, so
+// plugins that only implement Plugin keep working unchanged.
+type ContextPlugin interface {
+	// Init is called in the agent`s startup phase with a context that is
+	// cancelled when the agent is stopping.
+	Init(ctx context.Context) error
+	// Close is called in the agent`s cleanup phase.
+	Close() error
+}
+
+// ContextPostInit is the context-aware counterpart of PostInit, detected the
+// same way ContextPlugin is.
+type ContextPostInit interface {
+	// AfterInit is called once Init() of all plugins have returned without
+	// error, with the same context that was passed to Init.
+	AfterInit(ctx context.Context) error
+}
+
+// PluginName represents the name of a plugin.
+type PluginName string
+
+// NamedPlugin represents a Plugin with a name. Plugin is declared as interface{}
+// rather than Plugin because a plugin may instead implement ContextPlugin -
+// the two use the same method name with different signatures, so no single
+// concrete type can satisfy both. core.Agent resolves which lifecycle contract
+// a plugin satisfies via type assertion when it is initialized.
+type NamedPlugin struct {
+	PluginName
+	Plugin interface{}
+}
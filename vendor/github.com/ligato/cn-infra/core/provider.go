@@ -0,0 +1,197 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package core also exposes a Provider/Injector layer that lets plugin
+// composition be driven by a DI framework such as google/wire instead of the
+// "flavor + ListPluginsInFlavor" pattern of hand-built *NamedPlugin slices.
+//
+// A plugin that wants to be wire-friendly declares its own Deps struct and a
+// constructor next to its implementation, tagging each field that should be
+// filled from another resolved plugin with `wire:"<providerName>"`, where
+// <providerName> is the PluginName the dependency's own PluginProvider is
+// registered under:
+//
+//	// deps.go
+//	type Deps struct {
+//		Log  logging.Logger `wire:"logger"`
+//		Etcd *etcdv3.Plugin  `wire:"etcd"`
+//	}
+//
+//	func NewPlugin(deps Deps) (*Plugin, error) {
+//		return &Plugin{Deps: deps}, nil
+//	}
+//
+// There is no generator yet - every wire.go in this tree is hand-written -
+// but the wire tags above are not decorative: they pin down the mapping a
+// future generator would need, so writing one later is a mechanical exercise
+// in go/ast rather than a design problem. Until then, follow the same rules
+// by hand:
+//
+//  1. DependsOn is one core.PluginName literal per wire-tagged field, in
+//     struct field order: []core.PluginName{"logger", "etcd"}.
+//  2. New type-asserts deps.Get(tag) to each tagged field's declared type and
+//     assigns it into a Deps{} literal, leaving every untagged field (there
+//     are none in the example above) zero-valued for NewPlugin to default
+//     itself - untagged fields exist for values that are not themselves a
+//     resolved PluginProvider.
+//  3. Name is the package's own provider name, conventionally the last path
+//     element of the package the ProviderSet lives in.
+//
+// Applying these rules to the Deps struct above gives:
+//
+//	// wire.go
+//	var ProviderSet = core.PluginProvider{
+//		Name:      core.PluginName("mypackage"),
+//		DependsOn: []core.PluginName{"logger", "etcd"},
+//		New: func(deps core.Deps) (interface{}, error) {
+//			return NewPlugin(Deps{
+//				Log:  deps.Get("logger").(logging.Logger),
+//				Etcd: deps.Get("etcd").(*etcdv3.Plugin),
+//			})
+//		},
+//	}
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ligato/cn-infra/logging"
+)
+
+// PluginProvider describes how to construct one named plugin from its
+// dependencies. A flavor lists PluginProviders instead of building
+// *NamedPlugin values itself; core.Injector resolves them in dependency
+// order and flattens the result into a PluginSet.
+type PluginProvider struct {
+	// Name is the PluginName the constructed plugin is registered under and
+	// the name other providers refer to via DependsOn.
+	Name PluginName
+	// DependsOn lists the providers that must be resolved before this one
+	// runs. It plays the same role here that a plugin's own DependsOn()
+	// method plays for parallel Init() - in fact a provider's resulting
+	// plugin typically also implements DependsOn() for the same reason.
+	DependsOn []PluginName
+	// New constructs the plugin given its already-resolved dependencies.
+	New func(deps Deps) (interface{}, error)
+}
+
+// Deps is handed to every PluginProvider.New. It exposes by name every plugin
+// that has been resolved so far, which includes all of those listed in
+// DependsOn.
+type Deps struct {
+	resolved map[PluginName]interface{}
+}
+
+// Get returns the already-resolved plugin registered under name, or nil if
+// no such plugin has been resolved yet (for example because the calling
+// provider forgot to list it in DependsOn).
+func (d Deps) Get(name PluginName) interface{} {
+	return d.resolved[name]
+}
+
+// PluginSet is a resolved, ordered list of named plugins - the same shape
+// NewAgent has always accepted, just produced by an Injector instead of
+// written out by hand.
+type PluginSet []*NamedPlugin
+
+// Injector resolves a set of PluginProviders into a PluginSet, by calling
+// each provider's New function only after every provider it DependsOn has
+// already been resolved.
+type Injector struct {
+	providers []PluginProvider
+}
+
+// NewInjector returns an Injector that resolves the given providers.
+func NewInjector(providers ...PluginProvider) *Injector {
+	return &Injector{providers: providers}
+}
+
+// Resolve constructs every provider's plugin in dependency order and returns
+// them as a PluginSet, ready to pass to NewAgent.
+func (inj *Injector) Resolve() (PluginSet, error) {
+	order, err := sortProviders(inj.providers)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[PluginName]interface{}, len(order))
+	set := make(PluginSet, 0, len(order))
+	for _, provider := range order {
+		plugin, err := provider.New(Deps{resolved: resolved})
+		if err != nil {
+			return nil, fmt.Errorf("resolving plugin %s: %v", provider.Name, err)
+		}
+		resolved[provider.Name] = plugin
+		set = append(set, &NamedPlugin{PluginName: provider.Name, Plugin: plugin})
+	}
+	return set, nil
+}
+
+// sortProviders orders providers so that every provider appears after all of
+// its DependsOn, falling back to the given order among providers whose
+// relative order DependsOn does not constrain.
+func sortProviders(providers []PluginProvider) ([]PluginProvider, error) {
+	byName := make(map[PluginName]PluginProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name] = p
+	}
+
+	var order []PluginProvider
+	resolved := make(map[PluginName]bool, len(providers))
+	visiting := make(map[PluginName]bool, len(providers))
+
+	var visit func(p PluginProvider) error
+	visit = func(p PluginProvider) error {
+		if resolved[p.Name] {
+			return nil
+		}
+		if visiting[p.Name] {
+			return fmt.Errorf("cycle detected in plugin provider dependencies at %s", p.Name)
+		}
+		visiting[p.Name] = true
+		for _, depName := range p.DependsOn {
+			dep, ok := byName[depName]
+			if !ok {
+				return fmt.Errorf("plugin %s depends on unknown provider %s", p.Name, depName)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visiting[p.Name] = false
+		resolved[p.Name] = true
+		order = append(order, p)
+		return nil
+	}
+
+	for _, p := range providers {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// NewAgentFromProviders resolves providers via an Injector and flattens the
+// result into NewAgent, which remains the thin wrapper it always was. This
+// lets callers - including tests that want to swap a single plugin's
+// provider for a fake - compose an Agent without rebuilding a whole flavor.
+func NewAgentFromProviders(logger logging.Logger, maxStartup time.Duration, providers ...PluginProvider) (*Agent, error) {
+	set, err := NewInjector(providers...).Resolve()
+	if err != nil {
+		return nil, err
+	}
+	return NewAgent(logger, maxStartup, set...), nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"github.com/ligato/vpp-agent/plugins/linuxplugin/model/interfaces"
+
+	vpp_clientv1 "github.com/ligato/vpp-agent/clientv1/defaultplugins"
+	vpp_acl "github.com/ligato/vpp-agent/plugins/defaultplugins/aclplugin/model/acl"
+	vpp_intf "github.com/ligato/vpp-agent/plugins/defaultplugins/ifplugin/model/interfaces"
+	vpp_l2 "github.com/ligato/vpp-agent/plugins/defaultplugins/l2plugin/model/l2"
+	vpp_l3 "github.com/ligato/vpp-agent/plugins/defaultplugins/l3plugin/model/l3"
+)
+
+// itemCounter tallies how many configuration items were added to a
+// DataResyncDSL request, so ResyncScheduler can report ItemCount without
+// every BeforeResync hook having to track it itself.
+type itemCounter struct {
+	count int
+}
+
+// countingDSL wraps a DataResyncDSL, forwarding every call to the underlying
+// implementation while counting how many items were added through it.
+type countingDSL struct {
+	DataResyncDSL
+	counter *itemCounter
+}
+
+func (c countingDSL) LinuxInterface(intf *interfaces.LinuxInterfaces_Interface) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.LinuxInterface(intf)
+	return c
+}
+
+func (c countingDSL) VppInterface(intf *vpp_intf.Interfaces_Interface) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.VppInterface(intf)
+	return c
+}
+
+func (c countingDSL) BD(bd *vpp_l2.BridgeDomains_BridgeDomain) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.BD(bd)
+	return c
+}
+
+func (c countingDSL) BDFIB(fib *vpp_l2.FibTableEntries_FibTableEntry) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.BDFIB(fib)
+	return c
+}
+
+func (c countingDSL) XConnect(xcon *vpp_l2.XConnectPairs_XConnectPair) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.XConnect(xcon)
+	return c
+}
+
+func (c countingDSL) StaticRoute(staticRoute *vpp_l3.StaticRoutes_Route) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.StaticRoute(staticRoute)
+	return c
+}
+
+func (c countingDSL) ACL(acl *vpp_acl.AccessLists_Acl) DataResyncDSL {
+	c.counter.count++
+	c.DataResyncDSL.ACL(acl)
+	return c
+}
+
+func (c countingDSL) Send() vpp_clientv1.Reply {
+	return c.DataResyncDSL.Send()
+}
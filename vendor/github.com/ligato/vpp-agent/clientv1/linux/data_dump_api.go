@@ -0,0 +1,69 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"github.com/ligato/vpp-agent/plugins/linuxplugin/model/interfaces"
+
+	vpp_acl "github.com/ligato/vpp-agent/plugins/defaultplugins/aclplugin/model/acl"
+	vpp_intf "github.com/ligato/vpp-agent/plugins/defaultplugins/ifplugin/model/interfaces"
+	vpp_l2 "github.com/ligato/vpp-agent/plugins/defaultplugins/l2plugin/model/l2"
+	vpp_l3 "github.com/ligato/vpp-agent/plugins/defaultplugins/l3plugin/model/l3"
+)
+
+// DataDumpDSL is used to conveniently read back the Linux and VPP configuration that is
+// actually programmed, as opposed to DataResyncDSL which pushes the desired configuration.
+// Use this interface to make your implementation independent of local or particular remote
+// client. Controllers such as sfc-controller can use it to reconcile desired vs actual state
+// without maintaining a parallel cache of their own.
+type DataDumpDSL interface {
+	// DumpLinuxInterfaces returns all configured Linux interfaces, or only those matching the
+	// given keys when one or more are provided.
+	DumpLinuxInterfaces(key ...string) ([]*interfaces.LinuxInterfaces_Interface, error)
+	// DumpVppInterfaces returns all configured VPP interfaces, or only those matching the
+	// given keys when one or more are provided.
+	DumpVppInterfaces(key ...string) ([]*vpp_intf.Interfaces_Interface, error)
+	// DumpBDs returns all configured VPP Bridge Domains, or only those matching the given
+	// keys when one or more are provided.
+	DumpBDs(key ...string) ([]*vpp_l2.BridgeDomains_BridgeDomain, error)
+	// DumpBDFIBs returns all configured VPP L2 FIB entries, or only those matching the given
+	// keys when one or more are provided.
+	DumpBDFIBs(key ...string) ([]*vpp_l2.FibTableEntries_FibTableEntry, error)
+	// DumpXConnects returns all configured VPP Cross Connects, or only those matching the
+	// given keys when one or more are provided.
+	DumpXConnects(key ...string) ([]*vpp_l2.XConnectPairs_XConnectPair, error)
+	// DumpStaticRoutes returns all configured VPP L3 Static Routes, or only those matching the
+	// given keys when one or more are provided.
+	DumpStaticRoutes(key ...string) ([]*vpp_l3.StaticRoutes_Route, error)
+	// DumpACLs returns all configured VPP Access Control Lists, or only those matching the
+	// given keys when one or more are provided.
+	DumpACLs(key ...string) ([]*vpp_acl.AccessLists_Acl, error)
+
+	// DumpAll returns a single snapshot aggregating every resource type covered by
+	// DataResyncDSL, shaped so it can be fed straight back into a resync request.
+	DumpAll() (*DataDump, error)
+}
+
+// DataDump aggregates the result of DumpAll() into the same shape that DataResyncDSL
+// consumes, so a caller can diff or replay it without re-slicing the individual dumps.
+type DataDump struct {
+	LinuxInterfaces []*interfaces.LinuxInterfaces_Interface
+	VppInterfaces   []*vpp_intf.Interfaces_Interface
+	BDs             []*vpp_l2.BridgeDomains_BridgeDomain
+	BDFIBs          []*vpp_l2.FibTableEntries_FibTableEntry
+	XConnects       []*vpp_l2.XConnectPairs_XConnectPair
+	StaticRoutes    []*vpp_l3.StaticRoutes_Route
+	ACLs            []*vpp_acl.AccessLists_Acl
+}
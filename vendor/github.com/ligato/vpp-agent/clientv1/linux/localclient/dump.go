@@ -0,0 +1,251 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localclient
+
+import (
+	linuxclient "github.com/ligato/vpp-agent/clientv1/linux"
+	"github.com/ligato/vpp-agent/plugins/linuxplugin/model/interfaces"
+
+	vpp_acl "github.com/ligato/vpp-agent/plugins/defaultplugins/aclplugin/model/acl"
+	vpp_intf "github.com/ligato/vpp-agent/plugins/defaultplugins/ifplugin/model/interfaces"
+	vpp_l2 "github.com/ligato/vpp-agent/plugins/defaultplugins/l2plugin/model/l2"
+	vpp_l3 "github.com/ligato/vpp-agent/plugins/defaultplugins/l3plugin/model/l3"
+)
+
+// DataDumpDSL is a local implementation of linuxclient.DataDumpDSL that reads straight
+// out of the in-memory store shared with this package's RESYNC DSL.
+type DataDumpDSL struct{}
+
+// NewDataDumpDSL returns a DataDumpDSL reading from the local, in-process data store.
+func NewDataDumpDSL() *DataDumpDSL {
+	return &DataDumpDSL{}
+}
+
+// DumpLinuxInterfaces implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpLinuxInterfaces(key ...string) ([]*interfaces.LinuxInterfaces_Interface, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*interfaces.LinuxInterfaces_Interface, 0, len(db.linuxInterfaces))
+		for _, val := range db.linuxInterfaces {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterLinuxInterfaces(db.linuxInterfaces, key), nil
+}
+
+// DumpVppInterfaces implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpVppInterfaces(key ...string) ([]*vpp_intf.Interfaces_Interface, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_intf.Interfaces_Interface, 0, len(db.vppInterfaces))
+		for _, val := range db.vppInterfaces {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterVppInterfaces(db.vppInterfaces, key), nil
+}
+
+// DumpBDs implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpBDs(key ...string) ([]*vpp_l2.BridgeDomains_BridgeDomain, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_l2.BridgeDomains_BridgeDomain, 0, len(db.bds))
+		for _, val := range db.bds {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterBDs(db.bds, key), nil
+}
+
+// DumpBDFIBs implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpBDFIBs(key ...string) ([]*vpp_l2.FibTableEntries_FibTableEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_l2.FibTableEntries_FibTableEntry, 0, len(db.bdFIBs))
+		for _, val := range db.bdFIBs {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterBDFIBs(db.bdFIBs, key), nil
+}
+
+// DumpXConnects implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpXConnects(key ...string) ([]*vpp_l2.XConnectPairs_XConnectPair, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_l2.XConnectPairs_XConnectPair, 0, len(db.xConnects))
+		for _, val := range db.xConnects {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterXConnects(db.xConnects, key), nil
+}
+
+// DumpStaticRoutes implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpStaticRoutes(key ...string) ([]*vpp_l3.StaticRoutes_Route, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_l3.StaticRoutes_Route, 0, len(db.staticRoutes))
+		for _, val := range db.staticRoutes {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterStaticRoutes(db.staticRoutes, key), nil
+}
+
+// DumpACLs implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpACLs(key ...string) ([]*vpp_acl.AccessLists_Acl, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if len(key) == 0 {
+		result := make([]*vpp_acl.AccessLists_Acl, 0, len(db.acls))
+		for _, val := range db.acls {
+			result = append(result, val)
+		}
+		return result, nil
+	}
+	return filterACLs(db.acls, key), nil
+}
+
+// DumpAll implements linuxclient.DataDumpDSL.
+func (d *DataDumpDSL) DumpAll() (*linuxclient.DataDump, error) {
+	linuxIntfs, err := d.DumpLinuxInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	vppIntfs, err := d.DumpVppInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	bds, err := d.DumpBDs()
+	if err != nil {
+		return nil, err
+	}
+	bdFIBs, err := d.DumpBDFIBs()
+	if err != nil {
+		return nil, err
+	}
+	xConnects, err := d.DumpXConnects()
+	if err != nil {
+		return nil, err
+	}
+	staticRoutes, err := d.DumpStaticRoutes()
+	if err != nil {
+		return nil, err
+	}
+	acls, err := d.DumpACLs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &linuxclient.DataDump{
+		LinuxInterfaces: linuxIntfs,
+		VppInterfaces:   vppIntfs,
+		BDs:             bds,
+		BDFIBs:          bdFIBs,
+		XConnects:       xConnects,
+		StaticRoutes:    staticRoutes,
+		ACLs:            acls,
+	}, nil
+}
+
+func filterLinuxInterfaces(m map[string]*interfaces.LinuxInterfaces_Interface, keys []string) []*interfaces.LinuxInterfaces_Interface {
+	result := make([]*interfaces.LinuxInterfaces_Interface, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterVppInterfaces(m map[string]*vpp_intf.Interfaces_Interface, keys []string) []*vpp_intf.Interfaces_Interface {
+	result := make([]*vpp_intf.Interfaces_Interface, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterBDs(m map[string]*vpp_l2.BridgeDomains_BridgeDomain, keys []string) []*vpp_l2.BridgeDomains_BridgeDomain {
+	result := make([]*vpp_l2.BridgeDomains_BridgeDomain, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterBDFIBs(m map[string]*vpp_l2.FibTableEntries_FibTableEntry, keys []string) []*vpp_l2.FibTableEntries_FibTableEntry {
+	result := make([]*vpp_l2.FibTableEntries_FibTableEntry, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterXConnects(m map[string]*vpp_l2.XConnectPairs_XConnectPair, keys []string) []*vpp_l2.XConnectPairs_XConnectPair {
+	result := make([]*vpp_l2.XConnectPairs_XConnectPair, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterStaticRoutes(m map[string]*vpp_l3.StaticRoutes_Route, keys []string) []*vpp_l3.StaticRoutes_Route {
+	result := make([]*vpp_l3.StaticRoutes_Route, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+func filterACLs(m map[string]*vpp_acl.AccessLists_Acl, keys []string) []*vpp_acl.AccessLists_Acl {
+	result := make([]*vpp_acl.AccessLists_Acl, 0, len(keys))
+	for _, key := range keys {
+		if val, found := m[key]; found {
+			result = append(result, val)
+		}
+	}
+	return result
+}
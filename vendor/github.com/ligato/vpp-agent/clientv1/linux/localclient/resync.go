@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localclient
+
+import (
+	"fmt"
+
+	linuxclient "github.com/ligato/vpp-agent/clientv1/linux"
+	"github.com/ligato/vpp-agent/plugins/linuxplugin/model/interfaces"
+
+	vpp_clientv1 "github.com/ligato/vpp-agent/clientv1/defaultplugins"
+	vpp_acl "github.com/ligato/vpp-agent/plugins/defaultplugins/aclplugin/model/acl"
+	vpp_intf "github.com/ligato/vpp-agent/plugins/defaultplugins/ifplugin/model/interfaces"
+	vpp_l2 "github.com/ligato/vpp-agent/plugins/defaultplugins/l2plugin/model/l2"
+	vpp_l3 "github.com/ligato/vpp-agent/plugins/defaultplugins/l3plugin/model/l3"
+)
+
+// DataResyncDSL is a local implementation of linuxclient.DataResyncDSL that writes
+// straight into the in-memory store shared with this package's Dump DSL - no ETCD
+// or GRPC round trip required. Each item is written as soon as it is added, and
+// items of earlier requests that a later one omits are not removed, so a DumpXxx()
+// call returns everything added so far in this process, not only the last RESYNC.
+type DataResyncDSL struct{}
+
+// DataResyncRequest returns a DataResyncDSL that applies directly to the local,
+// in-process data store.
+func DataResyncRequest() *DataResyncDSL {
+	return &DataResyncDSL{}
+}
+
+// LinuxInterface implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) LinuxInterface(intf *interfaces.LinuxInterfaces_Interface) linuxclient.DataResyncDSL {
+	PutLinuxInterface(intf.Name, intf)
+	return d
+}
+
+// VppInterface implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) VppInterface(intf *vpp_intf.Interfaces_Interface) linuxclient.DataResyncDSL {
+	PutVppInterface(intf.Name, intf)
+	return d
+}
+
+// BD implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) BD(bd *vpp_l2.BridgeDomains_BridgeDomain) linuxclient.DataResyncDSL {
+	PutBD(bd.Name, bd)
+	return d
+}
+
+// BDFIB implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) BDFIB(fib *vpp_l2.FibTableEntries_FibTableEntry) linuxclient.DataResyncDSL {
+	PutBDFIB(fmt.Sprintf("%s/%s", fib.BridgeDomain, fib.PhysAddress), fib)
+	return d
+}
+
+// XConnect implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) XConnect(xcon *vpp_l2.XConnectPairs_XConnectPair) linuxclient.DataResyncDSL {
+	PutXConnect(xcon.ReceiveInterface, xcon)
+	return d
+}
+
+// StaticRoute implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) StaticRoute(route *vpp_l3.StaticRoutes_Route) linuxclient.DataResyncDSL {
+	PutStaticRoute(fmt.Sprintf("%d/%s/%s", route.VrfId, route.DstIpAddr, route.NextHopAddr), route)
+	return d
+}
+
+// ACL implements linuxclient.DataResyncDSL.
+func (d *DataResyncDSL) ACL(acl *vpp_acl.AccessLists_Acl) linuxclient.DataResyncDSL {
+	PutACL(acl.AclName, acl)
+	return d
+}
+
+// Send implements linuxclient.DataResyncDSL. Every item was already committed to
+// the shared store by the call that added it, so there is nothing left to
+// transport - Send just returns a Reply that is always successful.
+func (d *DataResyncDSL) Send() vpp_clientv1.Reply {
+	return &reply{}
+}
+
+// reply is the local, always-successful implementation of vpp_clientv1.Reply
+// returned by Send(), since there is no RPC round trip that could fail.
+type reply struct{}
+
+// ReceiveReply implements vpp_clientv1.Reply.
+func (r *reply) ReceiveReply() error {
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localclient
+
+import (
+	"sync"
+
+	"github.com/ligato/vpp-agent/plugins/linuxplugin/model/interfaces"
+
+	vpp_acl "github.com/ligato/vpp-agent/plugins/defaultplugins/aclplugin/model/acl"
+	vpp_intf "github.com/ligato/vpp-agent/plugins/defaultplugins/ifplugin/model/interfaces"
+	vpp_l2 "github.com/ligato/vpp-agent/plugins/defaultplugins/l2plugin/model/l2"
+	vpp_l3 "github.com/ligato/vpp-agent/plugins/defaultplugins/l3plugin/model/l3"
+)
+
+// db is the in-memory store that backs both the local RESYNC DSL and the local Dump
+// DSL within this process - no ETCD or GRPC round trip required. Each item is written
+// when it is added to a RESYNC request, before Send(), and items are never removed, so
+// a Dump() returns every item added by any request in this process, the latest per key.
+var db = newStore()
+
+type store struct {
+	mu sync.RWMutex
+
+	linuxInterfaces map[string]*interfaces.LinuxInterfaces_Interface
+	vppInterfaces   map[string]*vpp_intf.Interfaces_Interface
+	bds             map[string]*vpp_l2.BridgeDomains_BridgeDomain
+	bdFIBs          map[string]*vpp_l2.FibTableEntries_FibTableEntry
+	xConnects       map[string]*vpp_l2.XConnectPairs_XConnectPair
+	staticRoutes    map[string]*vpp_l3.StaticRoutes_Route
+	acls            map[string]*vpp_acl.AccessLists_Acl
+}
+
+func newStore() *store {
+	return &store{
+		linuxInterfaces: make(map[string]*interfaces.LinuxInterfaces_Interface),
+		vppInterfaces:   make(map[string]*vpp_intf.Interfaces_Interface),
+		bds:             make(map[string]*vpp_l2.BridgeDomains_BridgeDomain),
+		bdFIBs:          make(map[string]*vpp_l2.FibTableEntries_FibTableEntry),
+		xConnects:       make(map[string]*vpp_l2.XConnectPairs_XConnectPair),
+		staticRoutes:    make(map[string]*vpp_l3.StaticRoutes_Route),
+		acls:            make(map[string]*vpp_acl.AccessLists_Acl),
+	}
+}
+
+// PutLinuxInterface records the Linux interface under key, as applied by the local
+// RESYNC DSL. It is exported so DSL implementations can keep the Dump store in sync
+// as they apply changes.
+func PutLinuxInterface(key string, val *interfaces.LinuxInterfaces_Interface) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.linuxInterfaces[key] = val
+}
+
+// PutVppInterface records the VPP interface under key.
+func PutVppInterface(key string, val *vpp_intf.Interfaces_Interface) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.vppInterfaces[key] = val
+}
+
+// PutBD records the VPP Bridge Domain under key.
+func PutBD(key string, val *vpp_l2.BridgeDomains_BridgeDomain) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.bds[key] = val
+}
+
+// PutBDFIB records the VPP L2 FIB entry under key.
+func PutBDFIB(key string, val *vpp_l2.FibTableEntries_FibTableEntry) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.bdFIBs[key] = val
+}
+
+// PutXConnect records the VPP Cross Connect under key.
+func PutXConnect(key string, val *vpp_l2.XConnectPairs_XConnectPair) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.xConnects[key] = val
+}
+
+// PutStaticRoute records the VPP L3 Static Route under key.
+func PutStaticRoute(key string, val *vpp_l3.StaticRoutes_Route) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.staticRoutes[key] = val
+}
+
+// PutACL records the VPP Access Control List under key.
+func PutACL(key string, val *vpp_acl.AccessLists_Acl) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.acls[key] = val
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2017 Cisco and/or its affiliates.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ligato/cn-infra/core"
+)
+
+// maxBackoffDoublings caps how many times ResyncScheduler doubles the base
+// interval after consecutive Send() failures, so the backoff plateaus at 16x
+// ResyncInterval instead of growing without bound.
+const maxBackoffDoublings = 4
+
+// ResyncScheduler periodically rebuilds a full DataResyncDSL request and
+// sends it, on a timer with jitter and on demand, so that a controller (e.g.
+// sfc-controller) does not have to write its own resync timer, backoff and
+// completion metrics. Register the items making up the desired state via
+// BeforeResync. If agent is non-nil, Start subscribes to its event bus and
+// calls TriggerNow itself whenever a core.ReconnectEvent is published (e.g.
+// the etcd sync base reporting it reconnected) - callers that want to trigger
+// a resync for some other reason can still call TriggerNow directly.
+type ResyncScheduler struct {
+	newResync func() DataResyncDSL
+	agent     *core.Agent
+
+	// ResyncInterval is the steady-state period between full resyncs.
+	ResyncInterval time.Duration
+	// ResyncJitter randomizes each wait by up to +/- this duration, so that
+	// many controllers started at the same time do not resync in lockstep.
+	ResyncJitter time.Duration
+
+	mu     sync.Mutex
+	before []func(DataResyncDSL)
+
+	consecutiveFailures int
+
+	triggerCh   chan struct{}
+	eventCh     chan core.PluginEvent
+	unsubscribe func()
+	stopCh      chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+// NewResyncScheduler returns a ResyncScheduler that builds each resync
+// request via newResync. If agent is non-nil, a ResyncCompleted event is
+// published on its bus after every attempt.
+func NewResyncScheduler(newResync func() DataResyncDSL, resyncInterval, resyncJitter time.Duration, agent *core.Agent) *ResyncScheduler {
+	return &ResyncScheduler{
+		newResync:      newResync,
+		agent:          agent,
+		ResyncInterval: resyncInterval,
+		ResyncJitter:   resyncJitter,
+		triggerCh:      make(chan struct{}, 1),
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// BeforeResync registers a hook that is called, in registration order, with
+// the freshly created DataResyncDSL before it is sent. Use it to add the
+// items that make up your desired state.
+func (s *ResyncScheduler) BeforeResync(hook func(DataResyncDSL)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.before = append(s.before, hook)
+}
+
+// TriggerNow requests an immediate resync. It never blocks: if a trigger is
+// already pending, this call is a no-op. Called automatically for every
+// core.ReconnectEvent observed on the agent's event bus (see Start); callers
+// may also invoke it directly for any other reason to resync out of band.
+func (s *ResyncScheduler) TriggerNow() {
+	select {
+	case s.triggerCh <- struct{}{}:
+	default:
+	}
+}
+
+// Start begins the periodic resync loop in a background goroutine. If the
+// scheduler was constructed with a non-nil agent, it also subscribes to that
+// agent's event bus and calls TriggerNow for every core.ReconnectEvent
+// published on it, for as long as the scheduler runs.
+func (s *ResyncScheduler) Start() {
+	if s.agent != nil {
+		s.eventCh = make(chan core.PluginEvent, 8)
+		s.unsubscribe = s.agent.Subscribe(s.eventCh)
+		s.wg.Add(1)
+		go s.watchEvents()
+	}
+
+	s.wg.Add(1)
+	go s.run()
+}
+
+// Close ends the periodic resync loop and the event subscription started by
+// Start, and waits for both to exit. It is safe to call more than once, e.g.
+// from both an owning Plugin.Close() and direct shutdown code.
+func (s *ResyncScheduler) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+	return nil
+}
+
+// watchEvents reacts to core.ReconnectEvent published on the agent's event
+// bus by triggering an immediate resync, until Close is called.
+func (s *ResyncScheduler) watchEvents() {
+	defer s.wg.Done()
+	defer s.unsubscribe()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case event := <-s.eventCh:
+			if _, ok := event.(core.ReconnectEvent); ok {
+				s.TriggerNow()
+			}
+		}
+	}
+}
+
+func (s *ResyncScheduler) run() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-s.triggerCh:
+			s.resyncOnce()
+		case <-time.After(s.nextWait()):
+			s.resyncOnce()
+		}
+	}
+}
+
+// nextWait returns the steady-state interval jittered by up to +/- ResyncJitter,
+// or the current exponential backoff delay if the previous attempt failed.
+func (s *ResyncScheduler) nextWait() time.Duration {
+	s.mu.Lock()
+	failures := s.consecutiveFailures
+	s.mu.Unlock()
+
+	if failures > 0 {
+		return backoffDelay(s.ResyncInterval, failures)
+	}
+	if s.ResyncJitter <= 0 {
+		return s.ResyncInterval
+	}
+	return s.ResyncInterval + time.Duration(rand.Int63n(int64(2*s.ResyncJitter))) - s.ResyncJitter
+}
+
+// backoffDelay doubles the base interval per consecutive failure, up to
+// maxBackoffDoublings times, so repeated Send() failures back off instead of
+// hammering the same request every ResyncInterval.
+func backoffDelay(base time.Duration, failures int) time.Duration {
+	doublings := failures
+	if doublings > maxBackoffDoublings {
+		doublings = maxBackoffDoublings
+	}
+	return base << uint(doublings)
+}
+
+// resyncOnce builds one DataResyncDSL request via newResync, runs every
+// BeforeResync hook against it, sends it, and publishes ResyncCompleted.
+func (s *ResyncScheduler) resyncOnce() {
+	start := time.Now()
+
+	s.mu.Lock()
+	hooks := make([]func(DataResyncDSL), len(s.before))
+	copy(hooks, s.before)
+	s.mu.Unlock()
+
+	counter := &itemCounter{}
+	req := countingDSL{DataResyncDSL: s.newResync(), counter: counter}
+	for _, hook := range hooks {
+		hook(req)
+	}
+
+	err := req.Send().ReceiveReply()
+
+	s.mu.Lock()
+	if err != nil {
+		s.consecutiveFailures++
+	} else {
+		s.consecutiveFailures = 0
+	}
+	s.mu.Unlock()
+
+	if s.agent != nil {
+		s.agent.Publish(ResyncCompleted{
+			Duration:  time.Since(start),
+			ItemCount: counter.count,
+			Err:       err,
+		})
+	}
+}
+
+// ResyncCompleted is published on the core.Agent event bus after every
+// ResyncScheduler attempt, successful or not, so observers can track
+// reconciliation latency without instrumenting every controller by hand.
+type ResyncCompleted struct {
+	core.Event
+	Duration  time.Duration
+	ItemCount int
+	Err       error
+}